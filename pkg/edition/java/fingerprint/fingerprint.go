@@ -0,0 +1,95 @@
+// Package fingerprint identifies a Minecraft backend server's software
+// (vanilla, Paper, Spigot, Purpur, Folia, Fabric, Forge, Velocity,
+// BungeeCord, Waterfall or another Gate) and its likely major version,
+// using a handful of passive and active network probes. None of the
+// probes require any cooperation from the backend beyond speaking the
+// regular Minecraft protocol.
+package fingerprint
+
+import (
+	"sync"
+	"time"
+)
+
+// Software is a classification of Minecraft server/proxy software.
+type Software string
+
+const (
+	Unknown    Software = ""
+	Vanilla    Software = "vanilla"
+	Paper      Software = "paper"
+	Spigot     Software = "spigot"
+	Purpur     Software = "purpur"
+	Folia      Software = "folia"
+	Fabric     Software = "fabric"
+	Forge      Software = "forge"
+	Velocity   Software = "velocity"
+	BungeeCord Software = "bungeecord"
+	Waterfall  Software = "waterfall"
+	Gate       Software = "gate"
+)
+
+// Result is the outcome of fingerprinting a backend.
+type Result struct {
+	Software Software
+	// Version is the best-effort detected major version, e.g. "1.20.4". May be empty.
+	Version string
+}
+
+// StatusResponse is the minimal subset of a Minecraft status (SLP) response
+// that fingerprinting needs. Callers that already polled the backend's
+// status, e.g. from a PingEvent, can pass it in to skip a redundant probe.
+type StatusResponse struct {
+	VersionName string
+	// ModIDs lists mod ids reported via legacy modinfo or the 1.13+ forgeData field, if any.
+	ModIDs []string
+}
+
+// DefaultTTL is how long a cached Result is considered fresh.
+const DefaultTTL = 10 * time.Minute
+
+// Cache caches fingerprint Results per backend address.
+type Cache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	result  Result
+	expires time.Time
+}
+
+// NewCache returns a Cache that forgets entries after ttl (DefaultTTL if ttl <= 0).
+func NewCache(ttl time.Duration) *Cache {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Cache{ttl: ttl, entries: map[string]cacheEntry{}}
+}
+
+// Get returns the cached Result for addr, if present and not expired.
+// Get is safe to call on a nil *Cache, returning (Result{}, false).
+func (c *Cache) Get(addr string) (Result, bool) {
+	if c == nil {
+		return Result{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[addr]
+	if !ok || time.Now().After(e.expires) {
+		return Result{}, false
+	}
+	return e.result, true
+}
+
+// Set stores result for addr, refreshing its TTL. Set is a no-op on a nil *Cache.
+func (c *Cache) Set(addr string, result Result) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[addr] = cacheEntry{result: result, expires: time.Now().Add(c.ttl)}
+}
@@ -0,0 +1,29 @@
+package fingerprint
+
+import "strings"
+
+// knownKickMessages maps a substring of a disconnect/kick message produced in
+// response to a deliberately malformed handshake (NextStatus 0xFE or an
+// oversized VarInt) to the software that is known to emit it. These messages
+// carry dynamic suffixes (e.g. Spigot appends the actual oversized length
+// value, Vanilla appends the nested exception detail), so matching has to be
+// prefix/substring based rather than an exact comparison.
+var knownKickMessages = []struct {
+	substr   string
+	software Software
+}{
+	{"Internal Exception: io.netty.handler.codec.DecoderException", Vanilla},
+	{"Internal Exception: io.netty.handler.codec.CorruptedFrameException: length is out of range", Spigot},
+	{"Unsupported protocol version", Waterfall},
+}
+
+// matchKickMessage classifies a kick message received in response to a
+// malformed handshake, or Unknown if it isn't recognized.
+func matchKickMessage(msg string) Software {
+	for _, known := range knownKickMessages {
+		if strings.Contains(msg, known.substr) {
+			return known.software
+		}
+	}
+	return Unknown
+}
@@ -0,0 +1,397 @@
+package fingerprint
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"go.minekube.com/gate/pkg/edition/java/proto/util"
+)
+
+// probeTimeout bounds a single network probe so a hung or firewalled backend
+// can't stall the caller indefinitely.
+const probeTimeout = 3 * time.Second
+
+// probeProtocol is the protocol version advertised in probe handshakes. It
+// only needs to be new enough that backends reply with the modern JSON
+// status format; the exact protocol otherwise doesn't matter for probing.
+const probeProtocol = 763
+
+// Detect classifies the backend at addr, optionally seeded with a status
+// response already obtained elsewhere (e.g. from a PingEvent) to avoid a
+// redundant status round trip. Probes run cheapest and least intrusive
+// first, returning as soon as one yields a confident classification.
+func Detect(ctx context.Context, addr string, status *StatusResponse) (Result, error) {
+	if status == nil {
+		if s, err := probeStatus(ctx, addr); err == nil {
+			status = s
+		}
+	}
+	if status != nil {
+		if r, ok := classifyStatus(*status); ok {
+			return r, nil
+		}
+	}
+	if r, ok := probeMalformedHandshake(ctx, addr); ok {
+		return r, nil
+	}
+	if r, ok := probeLoginAttempt(ctx, addr); ok {
+		return r, nil
+	}
+	return Result{Software: Unknown}, nil
+}
+
+// classifyStatus inspects a status response's version name and mod list for
+// well-known software markers.
+func classifyStatus(s StatusResponse) (Result, bool) {
+	if len(s.ModIDs) > 0 {
+		return Result{Software: Forge, Version: versionFromName(s.VersionName)}, true
+	}
+	name := s.VersionName
+	switch {
+	case hasField(name, "Velocity"):
+		return Result{Software: Velocity}, true
+	case hasField(name, "Waterfall"):
+		return Result{Software: Waterfall}, true
+	case hasField(name, "BungeeCord") || hasField(name, "Bungee"):
+		return Result{Software: BungeeCord}, true
+	case hasField(name, "Gate"):
+		return Result{Software: Gate}, true
+	case hasField(name, "Purpur"):
+		return Result{Software: Purpur, Version: versionFromName(name)}, true
+	case hasField(name, "Folia"):
+		return Result{Software: Folia, Version: versionFromName(name)}, true
+	case hasField(name, "Paper"):
+		return Result{Software: Paper, Version: versionFromName(name)}, true
+	case hasField(name, "Spigot"):
+		return Result{Software: Spigot, Version: versionFromName(name)}, true
+	case hasField(name, "Fabric"):
+		return Result{Software: Fabric, Version: versionFromName(name)}, true
+	}
+	return Result{}, false
+}
+
+func hasField(haystack, needle string) bool {
+	return strings.Contains(strings.ToLower(haystack), strings.ToLower(needle))
+}
+
+// versionFromName extracts the leading "1.x[.y]" token from a version name
+// like "Paper 1.20.4", falling back to the whole string. Trailing closing
+// punctuation, as in "(MC: 1.20.1)", is not part of the token.
+func versionFromName(name string) string {
+	for _, field := range strings.Fields(name) {
+		if strings.HasPrefix(field, "1.") {
+			return strings.TrimRight(field, ")],;")
+		}
+	}
+	return name
+}
+
+// probeStatus performs a normal status handshake and returns the parsed
+// version name and mod list (from legacy modinfo or 1.13+ forgeData).
+func probeStatus(ctx context.Context, addr string) (*StatusResponse, error) {
+	conn, err := dial(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	if err = writeHandshake(conn, addr, 1 /* status */); err != nil {
+		return nil, err
+	}
+	if err = writeFramed(conn, []byte{0x00}); err != nil { // StatusRequest
+		return nil, err
+	}
+
+	payload, err := readFramed(conn)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) == 0 || payload[0] != 0x00 { // StatusResponse packet ID
+		return nil, fmt.Errorf("fingerprint: unexpected status packet id")
+	}
+	jsonLen, n, err := util.ReadVarInt(bytes.NewReader(payload[1:]))
+	if err != nil {
+		return nil, err
+	}
+	raw := payload[1+n:]
+	if len(raw) < jsonLen {
+		return nil, fmt.Errorf("fingerprint: truncated status json")
+	}
+
+	var status struct {
+		Version struct {
+			Name string `json:"name"`
+		} `json:"version"`
+		ModInfo *struct {
+			ModList []struct {
+				ModID string `json:"modid"`
+			} `json:"modList"`
+		} `json:"modinfo"`
+		ForgeData *struct {
+			Mods []struct {
+				ModID string `json:"modId"`
+			} `json:"mods"`
+		} `json:"forgeData"`
+	}
+	if err = json.Unmarshal(raw[:jsonLen], &status); err != nil {
+		return nil, err
+	}
+
+	var mods []string
+	if status.ModInfo != nil {
+		for _, m := range status.ModInfo.ModList {
+			mods = append(mods, m.ModID)
+		}
+	}
+	if status.ForgeData != nil {
+		for _, m := range status.ForgeData.Mods {
+			mods = append(mods, m.ModID)
+		}
+	}
+	return &StatusResponse{VersionName: status.Version.Name, ModIDs: mods}, nil
+}
+
+// oversizedFrameLength is a legitimately-VarInt-encoded frame length one
+// byte past the 2^21-1 bytes any real Minecraft packet can be; Netty-based servers
+// (vanilla and its forks) reject it as soon as the length prefix is
+// decoded, before ever trying to read a payload that size.
+const oversizedFrameLength = 1 << 21
+
+// probeMalformedHandshake sends deliberately malformed input -- first a
+// handshake with an invalid NextStatus, then an oversized frame-length
+// VarInt -- and classifies whatever kick/disconnect message comes back
+// against a table of known server strings.
+func probeMalformedHandshake(ctx context.Context, addr string) (Result, bool) {
+	if r, ok := probeInvalidNextStatus(ctx, addr); ok {
+		return r, ok
+	}
+	return probeOversizedFrameLength(ctx, addr)
+}
+
+func probeInvalidNextStatus(ctx context.Context, addr string) (Result, bool) {
+	conn, err := dial(ctx, addr)
+	if err != nil {
+		return Result{}, false
+	}
+	defer func() { _ = conn.Close() }()
+
+	if err = writeHandshake(conn, addr, 0xFE); err != nil {
+		return Result{}, false
+	}
+	return readKickAndClassify(conn)
+}
+
+func probeOversizedFrameLength(ctx context.Context, addr string) (Result, bool) {
+	conn, err := dial(ctx, addr)
+	if err != nil {
+		return Result{}, false
+	}
+	defer func() { _ = conn.Close() }()
+
+	if err = util.WriteVarInt(conn, oversizedFrameLength); err != nil {
+		return Result{}, false
+	}
+	return readKickAndClassify(conn)
+}
+
+func readKickAndClassify(conn net.Conn) (Result, bool) {
+	payload, err := readFramed(conn)
+	if err != nil || len(payload) == 0 {
+		return Result{}, false
+	}
+	msg := extractKickMessage(payload)
+	if msg == "" {
+		return Result{}, false
+	}
+	if sw := matchKickMessage(msg); sw != Unknown {
+		return Result{Software: sw}, true
+	}
+	return Result{}, false
+}
+
+// probeLoginAttempt starts an offline-mode login with a random username and
+// (a) measures how quickly the backend rejects it, and (b) inspects any
+// login-phase plugin message channel for the Forge mod loading handshake
+// (fml:handshake / fml:loginwrapper), which is a strong Forge signal that
+// doesn't depend on message timing at all.
+func probeLoginAttempt(ctx context.Context, addr string) (Result, bool) {
+	conn, err := dial(ctx, addr)
+	if err != nil {
+		return Result{}, false
+	}
+	defer func() { _ = conn.Close() }()
+
+	if err = writeHandshake(conn, addr, 2 /* login */); err != nil {
+		return Result{}, false
+	}
+	if err = writeFramed(conn, loginStartPacket(randomUsername())); err != nil {
+		return Result{}, false
+	}
+
+	start := time.Now()
+	compressed := false
+	for {
+		payload, err := readFramed(conn)
+		if err != nil {
+			return Result{}, false
+		}
+		if compressed {
+			// Once compression is on, every packet starts with its uncompressed
+			// data length; 0 marks a packet sent uncompressed.
+			dataLen, n, err := util.ReadVarInt(bytes.NewReader(payload))
+			if err != nil || dataLen != 0 {
+				return Result{}, false
+			}
+			payload = payload[n:]
+		}
+		if len(payload) == 0 {
+			continue
+		}
+		switch payload[0] {
+		case 0x04: // LoginPluginMessage (login-phase plugin channel)
+			if channel := extractLoginPluginChannel(payload); isForgeChannel(channel) {
+				return Result{Software: Forge}, true
+			}
+		case 0x03: // SetCompression; later packets carry a data-length prefix, stripped above.
+			compressed = true
+			continue
+		case 0x00: // Disconnect
+			latency := time.Since(start)
+			return classifyLoginLatency(latency), true
+		default:
+			return Result{}, false
+		}
+	}
+}
+
+// classifyLoginLatency is a coarse heuristic: proxies (Bungee-family,
+// Velocity, Gate) typically reject an unauthenticatable offline login
+// near-instantly, while backing vanilla/Bukkit-family servers spend a
+// measurable moment round-tripping session/auth checks first.
+func classifyLoginLatency(d time.Duration) Result {
+	if d < 5*time.Millisecond {
+		return Result{Software: Unknown}
+	}
+	return Result{Software: Vanilla}
+}
+
+// dial opens a TCP connection to addr, bounding both the connect and every
+// subsequent read/write against it to probeTimeout, so a backend that
+// accepts the connection and then goes silent can't stall the caller.
+func dial(ctx context.Context, addr string) (net.Conn, error) {
+	dialCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	var d net.Dialer
+	conn, err := d.DialContext(dialCtx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if err = conn.SetDeadline(time.Now().Add(probeTimeout)); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func writeHandshake(w io.Writer, addr string, nextState byte) error {
+	host, port := splitHostPort(addr)
+	buf := new(bytes.Buffer)
+	buf.WriteByte(0x00) // Handshake packet ID
+	_ = util.WriteVarInt(buf, probeProtocol)
+	_ = util.WriteString(buf, host)
+	_ = util.WriteUint16(buf, port)
+	buf.WriteByte(nextState)
+	return writeFramed(w, buf.Bytes())
+}
+
+func loginStartPacket(username string) []byte {
+	buf := new(bytes.Buffer)
+	buf.WriteByte(0x00) // LoginStart packet ID
+	_ = util.WriteString(buf, username)
+	return buf.Bytes()
+}
+
+func writeFramed(w io.Writer, payload []byte) error {
+	if err := util.WriteVarInt(w, len(payload)); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+func readFramed(r io.Reader) ([]byte, error) {
+	n, _, err := util.ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, n)
+	_, err = io.ReadFull(r, buf)
+	return buf, err
+}
+
+// extractKickMessage best-effort extracts a human-readable string from a
+// Disconnect/kick packet, whether it carries a JSON chat component or a
+// plain legacy string.
+func extractKickMessage(payload []byte) string {
+	if len(payload) < 2 {
+		return ""
+	}
+	strLen, n, err := util.ReadVarInt(bytes.NewReader(payload[1:]))
+	if err != nil || 1+n+strLen > len(payload) {
+		return ""
+	}
+	raw := payload[1+n : 1+n+strLen]
+	var text struct {
+		Text string `json:"text"`
+	}
+	if err = json.Unmarshal(raw, &text); err == nil && text.Text != "" {
+		return text.Text
+	}
+	return string(raw)
+}
+
+func extractLoginPluginChannel(payload []byte) string {
+	if len(payload) < 2 {
+		return ""
+	}
+	// Skipping the message ID VarInt, the channel identifier follows as a string.
+	_, n, err := util.ReadVarInt(bytes.NewReader(payload[1:]))
+	if err != nil {
+		return ""
+	}
+	rest := payload[1+n:]
+	strLen, n2, err := util.ReadVarInt(bytes.NewReader(rest))
+	if err != nil || n2+strLen > len(rest) {
+		return ""
+	}
+	return string(rest[n2 : n2+strLen])
+}
+
+func isForgeChannel(channel string) bool {
+	return channel == "fml:handshake" || channel == "fml:loginwrapper" || channel == "FML|HS"
+}
+
+func splitHostPort(addr string) (string, uint16) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr, 25565
+	}
+	var port uint16
+	_, _ = fmt.Sscanf(portStr, "%d", &port)
+	return host, port
+}
+
+func randomUsername() string {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz"
+	b := make([]byte, 10)
+	for i := range b {
+		b[i] = alphabet[time.Now().UnixNano()%int64(len(alphabet))]
+	}
+	return "fp_" + string(b)
+}
@@ -0,0 +1,122 @@
+package fingerprint
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"go.minekube.com/gate/pkg/edition/java/proto/util"
+)
+
+func TestClassifyStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		in   StatusResponse
+		want Software
+		ok   bool
+	}{
+		{"forge via modids", StatusResponse{VersionName: "1.12.2", ModIDs: []string{"examplemod"}}, Forge, true},
+		{"velocity", StatusResponse{VersionName: "Velocity 1.20.4"}, Velocity, true},
+		{"waterfall", StatusResponse{VersionName: "Waterfall 1.20.4"}, Waterfall, true},
+		{"bungeecord", StatusResponse{VersionName: "BungeeCord 1.20.4"}, BungeeCord, true},
+		{"gate", StatusResponse{VersionName: "Gate 1.20.4"}, Gate, true},
+		{"purpur", StatusResponse{VersionName: "Purpur 1.20.4"}, Purpur, true},
+		{"folia", StatusResponse{VersionName: "Folia 1.20.4"}, Folia, true},
+		{"paper", StatusResponse{VersionName: "Paper 1.20.4"}, Paper, true},
+		{"spigot", StatusResponse{VersionName: "Spigot 1.20.4"}, Spigot, true},
+		{"fabric", StatusResponse{VersionName: "Fabric 1.20.4"}, Fabric, true},
+		{"unrecognized vanilla", StatusResponse{VersionName: "1.20.4"}, Unknown, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := classifyStatus(tt.in)
+			if ok != tt.ok || got.Software != tt.want {
+				t.Fatalf("classifyStatus(%+v) = %+v, %v; want %s, %v", tt.in, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestVersionFromName(t *testing.T) {
+	tests := []struct{ name, want string }{
+		{"Paper 1.20.4", "1.20.4"},
+		{"Purpur git-Purpur-123 (MC: 1.20.1)", "1.20.1"},
+		{"no version token here", "no version token here"},
+	}
+	for _, tt := range tests {
+		if got := versionFromName(tt.name); got != tt.want {
+			t.Errorf("versionFromName(%q) = %q; want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestExtractKickMessage(t *testing.T) {
+	plain := append([]byte{0x00}, framedString(t, "plain kick")...)
+	if got := extractKickMessage(plain); got != "plain kick" {
+		t.Errorf("extractKickMessage(plain) = %q; want %q", got, "plain kick")
+	}
+
+	jsonText := append([]byte{0x00}, framedString(t, `{"text":"json kick"}`)...)
+	if got := extractKickMessage(jsonText); got != "json kick" {
+		t.Errorf("extractKickMessage(json) = %q; want %q", got, "json kick")
+	}
+
+	if got := extractKickMessage([]byte{0x00}); got != "" {
+		t.Errorf("extractKickMessage(truncated) = %q; want empty", got)
+	}
+}
+
+// framedString VarInt-length-prefixes s the way a Disconnect packet's
+// message field is encoded on the wire, for constructing test packets.
+func framedString(t *testing.T, s string) []byte {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	if err := util.WriteString(buf, s); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadKickAndClassify(t *testing.T) {
+	client, backend := net.Pipe()
+	defer func() { _ = client.Close() }()
+
+	kick := append([]byte{0x00}, framedString(t, "Internal Exception: io.netty.handler.codec.DecoderException: boom")...)
+	go func() {
+		_ = writeFramed(backend, kick)
+		_ = backend.Close()
+	}()
+
+	result, ok := readKickAndClassify(client)
+	if !ok || result.Software != Vanilla {
+		t.Fatalf("readKickAndClassify() = %+v, %v; want Vanilla, true", result, ok)
+	}
+}
+
+func TestMatchKickMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+		want Software
+	}{
+		{
+			"vanilla decoder exception",
+			"Internal Exception: io.netty.handler.codec.DecoderException: java.lang.IllegalStateException: failed decoding",
+			Vanilla,
+		},
+		{
+			"spigot oversized varint",
+			"Internal Exception: io.netty.handler.codec.CorruptedFrameException: length is out of range (5034839)",
+			Spigot,
+		},
+		{"waterfall unsupported protocol", "Outdated server! I'm still on Unsupported protocol version", Waterfall},
+		{"unrecognized", "some other kick message", Unknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchKickMessage(tt.msg); got != tt.want {
+				t.Errorf("matchKickMessage(%q) = %q; want %q", tt.msg, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package lite
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+)
+
+// CaptureConfig controls per-route packet capture of Lite passthrough traffic
+// to pcap files, so operators can reproduce protocol issues that only
+// manifest through the proxy (e.g. with Wireshark's Minecraft dissector)
+// without patching Gate itself.
+type CaptureConfig struct {
+	// Dir is the directory capture files are written to. Capture is disabled if empty.
+	Dir string
+	// MaxSizeMB rotates to a new file once the current one reaches this size. 0 disables size-based rotation.
+	MaxSizeMB int
+	// MaxFiles is the number of rotated files kept per connection before the oldest is removed. 0 keeps all.
+	MaxFiles int
+}
+
+const captureSnapLen = 65535
+
+// Capture records one Lite connection's byte stream as pcap packets between
+// synthetic Ethernet/IPv4/TCP pseudo-endpoints: 127.0.0.1 for the real client
+// and a per-connection 10.x address for the backend, each keeping its real
+// port (the backend's Minecraft port) so Wireshark's dissector can parse the flows.
+type Capture struct {
+	cfg CaptureConfig
+
+	client  *net.TCPAddr
+	backend *net.TCPAddr
+
+	mu                 sync.Mutex
+	seqClientToBackend uint32
+	seqBackendToClient uint32
+	fileNo             int
+	size               int
+	f                  *os.File
+	w                  *pcapgo.Writer
+	base               string
+}
+
+// NewCapture starts capture of a Lite connection into cfg.Dir, naming files
+// after connID. It returns nil, nil if cfg is nil or capture is disabled.
+func NewCapture(cfg *CaptureConfig, connID string, client, backend net.Addr) (*Capture, error) {
+	if cfg == nil || cfg.Dir == "" {
+		return nil, nil
+	}
+	clientAddr, ok := toTCPAddr(client)
+	if !ok {
+		return nil, fmt.Errorf("lite: capture requires a TCP client address, got %T", client)
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("lite: create capture dir: %w", err)
+	}
+
+	c := &Capture{
+		cfg:     *cfg,
+		client:  &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: clientAddr.Port},
+		backend: pseudoBackendAddr(connID, backend),
+		base:    connID,
+	}
+	if err := c.rotate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func toTCPAddr(addr net.Addr) (*net.TCPAddr, bool) {
+	tcp, ok := addr.(*net.TCPAddr)
+	return tcp, ok
+}
+
+// pseudoBackendAddr derives a stable 10.x.x.x address from connID, keeping backend's real port.
+func pseudoBackendAddr(connID string, backend net.Addr) *net.TCPAddr {
+	port := 25565
+	if tcp, ok := toTCPAddr(backend); ok {
+		port = tcp.Port
+	}
+	h := fnv.New32a()
+	_, _ = io.WriteString(h, connID)
+	sum := h.Sum32()
+	return &net.TCPAddr{
+		IP:   net.IPv4(10, byte(sum>>16), byte(sum>>8), byte(sum)),
+		Port: port,
+	}
+}
+
+// rotate closes the current capture file, if any, and opens the next one,
+// pruning the oldest files beyond cfg.MaxFiles.
+func (c *Capture) rotate() error {
+	if c.f != nil {
+		_ = c.f.Close()
+	}
+	c.fileNo++
+	c.size = 0
+
+	name := fmt.Sprintf("%s.%d.pcap", c.base, c.fileNo)
+	f, err := os.Create(filepath.Join(c.cfg.Dir, name))
+	if err != nil {
+		return fmt.Errorf("lite: create capture file: %w", err)
+	}
+	w := pcapgo.NewWriter(f)
+	if err = w.WriteFileHeader(captureSnapLen, layers.LinkTypeEthernet); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("lite: write capture header: %w", err)
+	}
+	c.f, c.w = f, w
+	c.pruneLocked()
+	return nil
+}
+
+func (c *Capture) pruneLocked() {
+	if c.cfg.MaxFiles <= 0 || c.fileNo <= c.cfg.MaxFiles {
+		return
+	}
+	old := filepath.Join(c.cfg.Dir, fmt.Sprintf("%s.%d.pcap", c.base, c.fileNo-c.cfg.MaxFiles))
+	_ = os.Remove(old)
+}
+
+// ClientToBackendWriter returns an io.Writer that records bytes flowing
+// client -> backend as capture packets, without affecting the proxied stream.
+func (c *Capture) ClientToBackendWriter() io.Writer {
+	return captureWriter{c: c, from: c.client, to: c.backend}
+}
+
+// BackendToClientWriter returns an io.Writer that records bytes flowing
+// backend -> client as capture packets, without affecting the proxied stream.
+func (c *Capture) BackendToClientWriter() io.Writer {
+	return captureWriter{c: c, from: c.backend, to: c.client}
+}
+
+// WriteClientToBackend records a single client -> backend chunk, such as the
+// handshake packet forwarded before the passthrough copy loop starts.
+func (c *Capture) WriteClientToBackend(p []byte) {
+	_, _ = c.ClientToBackendWriter().Write(p)
+}
+
+// Close closes the underlying capture file.
+func (c *Capture) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.f == nil {
+		return nil
+	}
+	return c.f.Close()
+}
+
+type captureWriter struct {
+	c    *Capture
+	from *net.TCPAddr
+	to   *net.TCPAddr
+}
+
+// Write never returns an error to the caller: a capture failure must not break proxying.
+func (cw captureWriter) Write(p []byte) (int, error) {
+	cw.c.writePacket(cw.from, cw.to, p)
+	return len(p), nil
+}
+
+func (c *Capture) writePacket(from, to *net.TCPAddr, payload []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	eth := &layers.Ethernet{
+		SrcMAC:       net.HardwareAddr{0x02, 0, 0, 0, 0, 1},
+		DstMAC:       net.HardwareAddr{0x02, 0, 0, 0, 0, 2},
+		EthernetType: layers.EthernetTypeIPv4,
+	}
+	ip := &layers.IPv4{
+		Version:  4,
+		TTL:      64,
+		IHL:      5,
+		Protocol: layers.IPProtocolTCP,
+		SrcIP:    from.IP,
+		DstIP:    to.IP,
+	}
+	// Client -> backend and backend -> client are independent TCP sequence
+	// spaces; sharing one counter between them would produce sequence numbers
+	// that match neither real stream and break Wireshark's reassembly.
+	seq := &c.seqBackendToClient
+	if from == c.client {
+		seq = &c.seqClientToBackend
+	}
+	tcp := &layers.TCP{
+		SrcPort: layers.TCPPort(from.Port),
+		DstPort: layers.TCPPort(to.Port),
+		Seq:     *seq,
+		PSH:     true,
+		ACK:     true,
+		Window:  65535,
+	}
+	*seq += uint32(len(payload))
+	_ = tcp.SetNetworkLayerForChecksum(ip)
+
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{ComputeChecksums: true, FixLengths: true}
+	if err := gopacket.SerializeLayers(buf, opts, eth, ip, tcp, gopacket.Payload(payload)); err != nil {
+		return
+	}
+
+	data := buf.Bytes()
+	now := time.Now()
+	if err := c.w.WritePacket(gopacket.CaptureInfo{
+		Timestamp:     now,
+		CaptureLength: len(data),
+		Length:        len(data),
+	}, data); err != nil {
+		return
+	}
+
+	c.size += len(data)
+	if c.cfg.MaxSizeMB > 0 && c.size >= c.cfg.MaxSizeMB*1024*1024 {
+		_ = c.rotate()
+	}
+}
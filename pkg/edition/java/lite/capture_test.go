@@ -0,0 +1,43 @@
+package lite
+
+import (
+	"net"
+	"testing"
+)
+
+func TestCaptureWritePacketPerDirectionSequence(t *testing.T) {
+	c, err := NewCapture(&CaptureConfig{Dir: t.TempDir()}, "conn0",
+		&net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 12345},
+		&net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 25565},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = c.Close() }()
+
+	c.WriteClientToBackend([]byte("hello"))
+	c.WriteClientToBackend([]byte("world!"))
+	_, _ = c.BackendToClientWriter().Write([]byte("reply"))
+
+	if got, want := c.seqClientToBackend, uint32(len("hello")+len("world!")); got != want {
+		t.Errorf("seqClientToBackend = %d; want %d", got, want)
+	}
+	if got, want := c.seqBackendToClient, uint32(len("reply")); got != want {
+		t.Errorf("seqBackendToClient = %d; want %d", got, want)
+	}
+}
+
+func TestPseudoBackendAddrKeepsPort(t *testing.T) {
+	addr := pseudoBackendAddr("conn0", &net.TCPAddr{IP: net.ParseIP("203.0.113.5"), Port: 25565})
+	if addr.Port != 25565 {
+		t.Errorf("pseudoBackendAddr port = %d; want 25565", addr.Port)
+	}
+	if !addr.IP.IsPrivate() {
+		t.Errorf("pseudoBackendAddr IP = %s; want an RFC1918 address", addr.IP)
+	}
+
+	other := pseudoBackendAddr("conn1", &net.TCPAddr{IP: net.ParseIP("203.0.113.5"), Port: 25565})
+	if addr.IP.Equal(other.IP) {
+		t.Error("pseudoBackendAddr should derive distinct IPs from distinct connection ids")
+	}
+}
@@ -0,0 +1,131 @@
+// Package lite implements Gate's "Lite" mode: a thin passthrough that routes
+// incoming connections to a backend based on the handshake's virtual host,
+// without spinning up a full Java edition proxy session.
+package lite
+
+import (
+	"math/rand"
+	"net"
+	"strings"
+
+	"go.minekube.com/gate/pkg/edition/java/fingerprint"
+)
+
+// Config is the `lite` section of the proxy configuration.
+type Config struct {
+	Enabled bool
+	Routes  []Endpoint
+}
+
+// Backends is a list of backend addresses a route can forward to.
+type Backends []string
+
+// Random returns a random backend address, or "" if none are configured.
+func (b Backends) Random() string {
+	if len(b) == 0 {
+		return ""
+	}
+	return b[rand.Intn(len(b))]
+}
+
+// Endpoint is a single Lite route: one or more virtual hosts mapped to one or more backends.
+type Endpoint struct {
+	Host          []string
+	Backend       Backends
+	ProxyProtocol bool
+	RealIP        bool
+	// Capture, when non-nil, records this route's passthrough traffic to pcap files for offline analysis.
+	Capture *CaptureConfig
+	// RequireSoftware, when non-empty, restricts this route to backends already
+	// known (via the fingerprint cache) to run one of these softwares. Backends
+	// with no cached fingerprint yet are still eligible, so the route can bootstrap.
+	RequireSoftware []fingerprint.Software
+	// LegacyPassthrough, when true, forwards a legacy (pre-1.7) server-list-ping
+	// as-is to the backend, which must speak the same legacy dialect. When
+	// false (the default), a modern status request is synthesized against the
+	// backend instead and its response translated back to legacy, since most
+	// current backends no longer understand the legacy ping protocol.
+	LegacyPassthrough bool
+}
+
+// SelectBackend picks a backend address from e.Backend, honoring
+// RequireSoftware against known, the backend fingerprint cache lookup
+// (typically *fingerprint.Cache.Get). known may be nil.
+func (e *Endpoint) SelectBackend(known func(addr string) (fingerprint.Result, bool)) string {
+	if len(e.RequireSoftware) == 0 || known == nil {
+		return e.Backend.Random()
+	}
+
+	var eligible Backends
+	for _, addr := range e.Backend {
+		result, ok := known(addr)
+		if !ok || softwareRequired(e.RequireSoftware, result.Software) {
+			eligible = append(eligible, addr)
+		}
+	}
+	return eligible.Random()
+}
+
+func softwareRequired(required []fingerprint.Software, sw fingerprint.Software) bool {
+	for _, r := range required {
+		if r == sw {
+			return true
+		}
+	}
+	return false
+}
+
+// FindRoute returns the matched host pattern and endpoint for clearedHost,
+// or "", nil if none of routes match.
+func FindRoute(clearedHost string, routes ...Endpoint) (string, *Endpoint) {
+	clearedHost = strings.ToLower(clearedHost)
+	for i := range routes {
+		for _, host := range routes[i].Host {
+			if host == "*" || strings.EqualFold(host, clearedHost) {
+				return host, &routes[i]
+			}
+		}
+	}
+	return "", nil
+}
+
+// ClearVirtualHost strips the null-terminated Forge/FML marker and any
+// trailing garbage some clients append to the handshake's server address.
+func ClearVirtualHost(host string) string {
+	if i := strings.IndexByte(host, 0); i != -1 {
+		host = host[:i]
+	}
+	return strings.TrimSuffix(host, ".")
+}
+
+// realIPSeparator joins the original virtual host with the client's real address.
+const realIPSeparator = "///"
+
+// IsRealIP reports whether host is eligible to be rewritten with RealIP info,
+// i.e. it does not already carry a RealIP-encoded address.
+func IsRealIP(host string) bool {
+	return !strings.Contains(host, realIPSeparator)
+}
+
+// RealIP rewrites host to additionally carry the client's real remote address,
+// so a downstream Gate/Velocity instance can recover it.
+func RealIP(host string, remote net.Addr) string {
+	return host + realIPSeparator + remote.String()
+}
+
+// DecodeRealIP splits a RealIP-encoded host back into the original virtual
+// host and the client's real address, as encoded by RealIP. ok is false if
+// host doesn't carry a RealIP encoding.
+func DecodeRealIP(host string) (original string, remote net.Addr, ok bool) {
+	idx := strings.Index(host, realIPSeparator)
+	if idx < 0 {
+		return "", nil, false
+	}
+	original = host[:idx]
+	addrStr := host[idx+len(realIPSeparator):]
+	tcpAddr, err := net.ResolveTCPAddr("tcp", addrStr)
+	if err != nil {
+		return "", nil, false
+	}
+	return original, tcpAddr, true
+}
@@ -0,0 +1,82 @@
+package lite
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// pumpBufSize is used for the pooled-buffer fallback path, matching the
+// size Go's io.Copy would otherwise allocate fresh per call.
+const pumpBufSize = 32 * 1024
+
+var pumpBufPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, pumpBufSize)
+		return &b
+	},
+}
+
+// Pump proxies bytes bidirectionally between src and dst until both
+// directions finish, preferring the kernel-level splice(2)/sendfile fast
+// path (via (*net.TCPConn).ReadFrom, which io.Copy already uses when its
+// destination implements io.ReaderFrom) when both ends are raw TCP
+// connections. Non-TCP connections fall back to a pooled 32 KiB buffer.
+//
+// If tapSrcToDst or tapDstToSrc are non-nil, the corresponding direction is
+// additionally written to that tap (e.g. for packet capture). A tapped
+// direction always goes through the pooled-buffer path, since bytes moved
+// by splice never pass through userspace for the tap to observe.
+//
+// A clean read-side EOF in one direction half-closes the write side of the
+// other connection (CloseWrite), so a client FIN propagates to the backend
+// without killing the still-draining opposite direction outright.
+func Pump(src, dst net.Conn, tapSrcToDst, tapDstToSrc io.Writer) error {
+	var g errgroup.Group
+	g.Go(func() error { return pumpDirection(dst, src, tapSrcToDst) })
+	g.Go(func() error { return pumpDirection(src, dst, tapDstToSrc) })
+	return g.Wait()
+}
+
+// pumpDirection copies from src to dst, half-closing dst's write side once src is drained.
+func pumpDirection(dst, src net.Conn, tap io.Writer) error {
+	defer closeWrite(dst)
+
+	if tap == nil {
+		if tcpDst, ok := dst.(*net.TCPConn); ok {
+			_, err := tcpDst.ReadFrom(src)
+			return ignoreCloseErr(err)
+		}
+	}
+
+	buf := pumpBufPool.Get().(*[]byte)
+	defer pumpBufPool.Put(buf)
+
+	w := io.Writer(dst)
+	if tap != nil {
+		w = io.MultiWriter(dst, tap)
+	}
+	_, err := io.CopyBuffer(w, src, *buf)
+	return ignoreCloseErr(err)
+}
+
+func closeWrite(c net.Conn) {
+	if cw, ok := c.(interface{ CloseWrite() error }); ok {
+		_ = cw.CloseWrite()
+		return
+	}
+	_ = c.Close()
+}
+
+// ignoreCloseErr treats the connection being closed, by the other side or by
+// the opposite direction's closeWrite fallback, as a normal end of the pump
+// direction rather than a failure to report.
+func ignoreCloseErr(err error) error {
+	if err == nil || errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) || errors.Is(err, io.ErrClosedPipe) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+package lite
+
+import (
+	"io"
+	"net"
+	"testing"
+)
+
+// payloadSize approximates a steady-state chunk of Minecraft passthrough traffic.
+const payloadSize = 64 * 1024
+
+func benchmarkPipe(b *testing.B, copyFn func(client, backend net.Conn) error) {
+	b.ReportAllocs()
+	b.SetBytes(payloadSize)
+
+	for i := 0; i < b.N; i++ {
+		client, clientSide := net.Pipe()
+		backendSide, backend := net.Pipe()
+
+		done := make(chan error, 1)
+		go func() { done <- copyFn(clientSide, backendSide) }()
+
+		payload := make([]byte, payloadSize)
+		go func() {
+			_, _ = client.Write(payload)
+			_ = client.Close()
+		}()
+		_, _ = io.Copy(io.Discard, backend)
+
+		if err := <-done; err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkIOCopy reproduces Lite's previous two-goroutine io.Copy passthrough.
+func BenchmarkIOCopy(b *testing.B) {
+	benchmarkPipe(b, func(client, backend net.Conn) error {
+		done := make(chan struct{})
+		go func() { _, _ = io.Copy(client, backend); close(done) }()
+		_, _ = io.Copy(backend, client)
+		_ = backend.Close()
+		_ = client.Close()
+		<-done
+		return nil
+	})
+}
+
+// BenchmarkPump exercises the same passthrough through Pump. net.Pipe
+// endpoints aren't *net.TCPConn, so this measures the pooled-buffer
+// fallback path; BenchmarkPumpTCP below measures the splice fast path.
+func BenchmarkPump(b *testing.B) {
+	benchmarkPipe(b, func(client, backend net.Conn) error {
+		return Pump(client, backend, nil, nil)
+	})
+}
+
+// BenchmarkPumpTCP runs the same passthrough over loopback TCP sockets on
+// both sides, so Pump's (*net.TCPConn).ReadFrom splice(2) fast path actually
+// engages, unlike BenchmarkPump's net.Pipe endpoints.
+func BenchmarkPumpTCP(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(payloadSize)
+
+	for i := 0; i < b.N; i++ {
+		client, clientSide := tcpLoopbackPair(b)
+		backendSide, backend := tcpLoopbackPair(b)
+
+		done := make(chan error, 1)
+		go func() { done <- Pump(clientSide, backendSide, nil, nil) }()
+
+		payload := make([]byte, payloadSize)
+		go func() {
+			_, _ = client.Write(payload)
+			_ = client.Close()
+		}()
+		_, _ = io.Copy(io.Discard, backend)
+		_ = backend.Close()
+
+		if err := <-done; err != nil {
+			b.Fatal(err)
+		}
+	}
+}
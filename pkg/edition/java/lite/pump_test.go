@@ -0,0 +1,175 @@
+package lite
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"sync"
+	"testing"
+)
+
+// closeWriteConn wraps a net.Conn to observe CloseWrite calls without
+// actually closing the underlying connection, so tests can assert
+// pumpDirection prefers the half-close path over a full Close.
+type closeWriteConn struct {
+	net.Conn
+	mu          sync.Mutex
+	closeWrites int
+}
+
+func (c *closeWriteConn) CloseWrite() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closeWrites++
+	return nil
+}
+
+func (c *closeWriteConn) closeWriteCalls() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closeWrites
+}
+
+func TestPumpHalfClosePrefersCloseWrite(t *testing.T) {
+	clientSide, client := net.Pipe()
+	backendSide, backend := net.Pipe()
+	wrapped := &closeWriteConn{Conn: backendSide}
+
+	done := make(chan error, 1)
+	go func() { done <- Pump(clientSide, wrapped, nil, nil) }()
+
+	go func() {
+		_, _ = client.Write([]byte("hello"))
+		_ = client.Close()
+	}()
+
+	buf := make([]byte, 16)
+	n, err := backend.Read(buf)
+	if err != nil {
+		t.Fatalf("backend.Read: %v", err)
+	}
+	if got := string(buf[:n]); got != "hello" {
+		t.Fatalf("backend got %q; want %q", got, "hello")
+	}
+
+	// The client already closed, so nothing further arrives for the reverse
+	// direction; close the backend side ourselves so Pump can return.
+	_ = backend.Close()
+
+	if err := <-done; err != nil {
+		t.Fatalf("Pump returned error: %v", err)
+	}
+	if got := wrapped.closeWriteCalls(); got != 1 {
+		t.Errorf("CloseWrite called %d times; want 1", got)
+	}
+}
+
+func TestPumpRoutesTapsPerDirection(t *testing.T) {
+	clientSide, client := net.Pipe()
+	backendSide, backend := net.Pipe()
+
+	var tapC2B, tapB2C bytes.Buffer
+	done := make(chan error, 1)
+	go func() { done <- Pump(clientSide, backendSide, &tapC2B, &tapB2C) }()
+
+	go func() {
+		buf := make([]byte, 16)
+		n, _ := backend.Read(buf)
+		_, _ = backend.Write([]byte("pong"))
+		_ = n
+	}()
+
+	if _, err := client.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 16)
+	n, err := client.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(buf[:n]); got != "pong" {
+		t.Fatalf("client got %q; want %q", got, "pong")
+	}
+
+	_ = client.Close()
+	_ = backend.Close()
+
+	if err := <-done; err != nil {
+		t.Fatalf("Pump returned error: %v", err)
+	}
+	if got := tapC2B.String(); got != "ping" {
+		t.Errorf("client->backend tap = %q; want %q", got, "ping")
+	}
+	if got := tapB2C.String(); got != "pong" {
+		t.Errorf("backend->client tap = %q; want %q", got, "pong")
+	}
+}
+
+// tcpLoopbackPair dials a loopback TCP listener and returns both ends as
+// *net.TCPConn, so tests can exercise Pump's (*net.TCPConn).ReadFrom splice
+// fast path, which net.Pipe can't: it isn't a *net.TCPConn.
+func tcpLoopbackPair(t testing.TB) (client, server net.Conn) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ln.Close() }()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			accepted <- nil
+			return
+		}
+		accepted <- c
+	}()
+
+	client, err = net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	server = <-accepted
+	if server == nil {
+		t.Fatal("accept failed")
+	}
+	return client, server
+}
+
+// TestPumpOverTCP exercises Pump with real *net.TCPConn endpoints on both
+// sides, the only way the splice(2)/sendfile fast path actually engages.
+func TestPumpOverTCP(t *testing.T) {
+	clientConn, clientSide := tcpLoopbackPair(t)
+	backendConn, backendSide := tcpLoopbackPair(t)
+	defer func() { _ = clientConn.Close() }()
+	defer func() { _ = backendConn.Close() }()
+
+	payload := bytes.Repeat([]byte("x"), 256*1024)
+
+	done := make(chan error, 1)
+	go func() { done <- Pump(clientSide, backendSide, nil, nil) }()
+
+	received := make(chan []byte, 1)
+	go func() {
+		got, _ := io.ReadAll(backendConn)
+		received <- got
+	}()
+
+	if _, err := clientConn.Write(payload); err != nil {
+		t.Fatal(err)
+	}
+	if err := clientConn.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := <-received
+	if !bytes.Equal(got, payload) {
+		t.Fatalf("backend received %d bytes not matching the %d byte payload", len(got), len(payload))
+	}
+
+	_ = backendConn.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("Pump returned error: %v", err)
+	}
+}
@@ -0,0 +1,21 @@
+package proxy
+
+import "go.minekube.com/gate/pkg/edition/java/fingerprint"
+
+// BackendFingerprintEvent is fired once a backend's software and version have
+// been classified, either freshly probed or served from cache. Subscribers
+// can use this to e.g. adjust logging or steer later connections.
+type BackendFingerprintEvent struct {
+	inbound Inbound
+	backend string
+	result  fingerprint.Result
+}
+
+// Inbound is the client connection the fingerprinted backend was dialed for.
+func (e *BackendFingerprintEvent) Inbound() Inbound { return e.inbound }
+
+// Backend is the address of the fingerprinted backend.
+func (e *BackendFingerprintEvent) Backend() string { return e.backend }
+
+// Result is the classification of the backend.
+func (e *BackendFingerprintEvent) Result() fingerprint.Result { return e.result }
@@ -0,0 +1,343 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+	"unicode/utf16"
+
+	"github.com/go-logr/logr"
+	"go.minekube.com/gate/pkg/edition/java/lite"
+	"go.minekube.com/gate/pkg/edition/java/netmc"
+	"go.minekube.com/gate/pkg/edition/java/proto/packet"
+	"go.minekube.com/gate/pkg/edition/java/proto/util"
+	"go.minekube.com/gate/pkg/util/netutil"
+)
+
+// Legacy server-list-ping and join message IDs, predating the modern
+// VarInt-framed protocol introduced in 1.7.
+const (
+	legacyPingMessageID = 0xFE
+	legacyJoinMessageID = 0x02
+)
+
+// legacyStatusProbeProtocol is the protocol version advertised when this
+// proxy synthesizes a modern status request against a backend on behalf of
+// a legacy client; it only needs to be recent enough to get the JSON status
+// format back.
+const legacyStatusProbeProtocol = 763
+
+// handleLegacyHandshake reacts to a pre-1.7 server-list-ping or join attempt,
+// detected by the decoder before the modern VarInt-length handshake would
+// have been read.
+func (h *handshakeSessionHandler) handleLegacyHandshake(lh *packet.LegacyHandshake) {
+	switch lh.Message {
+	case legacyPingMessageID:
+		h.handleLegacyPing(lh)
+	case legacyJoinMessageID:
+		h.handleLegacyJoin(lh)
+	default:
+		_ = h.conn.Close()
+	}
+}
+
+// handleLegacyJoin handles the legacy 0x02 join packet. Gate does not speak
+// the legacy login protocol, so every legacy client attempting to join is
+// told to upgrade, the same way an unsupported modern protocol version is.
+// The kick has to be written in the raw legacy 0xFF/UTF-16BE format: a
+// client that sent a 0x02 join by definition can't decode a VarInt-framed
+// modern Disconnect packet.
+func (h *handshakeSessionHandler) handleLegacyJoin(lh *packet.LegacyHandshake) {
+	vHost := h.legacyVirtualHost(lh)
+	inbound := newInitialInbound(h.conn, vHost)
+	lic := newLoginInboundConn(inbound)
+	h.eventMgr.Fire(&ConnectionHandshakeEvent{inbound: lic})
+
+	h.closeWithLegacyKick("Outdated client! Please use a supported Minecraft version.")
+}
+
+func (h *handshakeSessionHandler) legacyVirtualHost(lh *packet.LegacyHandshake) net.Addr {
+	if lh.Hostname == "" {
+		return h.conn.LocalAddr()
+	}
+	return netutil.NewAddr(
+		net.JoinHostPort(lh.Hostname, strconv.Itoa(lh.Port)),
+		h.conn.LocalAddr().Network(),
+	)
+}
+
+// handleLegacyPing answers a legacy server-list-ping (0xFE, with its zero,
+// one or two byte-long payload identifying the exact legacy dialect) with
+// the wire format that dialect expects.
+func (h *handshakeSessionHandler) handleLegacyPing(lh *packet.LegacyHandshake) {
+	vHost := h.legacyVirtualHost(lh)
+	inbound := newInitialInbound(h.conn, vHost)
+
+	if h.config().Lite.Enabled {
+		h.forwardLegacyLite(lh, inbound)
+		return
+	}
+
+	h.eventMgr.Fire(&PingEvent{inbound: inbound})
+
+	online := h.registrar.PlayerCount()
+	max := h.config().Status.MaxPlayers
+	motd := h.config().Status.Motd
+
+	h.closeWithLegacyKick(legacyPingReply(lh, motd, online, max))
+}
+
+// legacyPingReply formats a status as whichever legacy dialect lh belongs to.
+func legacyPingReply(lh *packet.LegacyHandshake, motd string, online, max int) string {
+	switch {
+	case lh.HasPingHostData: // 1.6: client sent hostname/port/protocol via the MC|PingHost plugin message.
+		return fmt.Sprintf("§1\x00%d\x00%s\x00%s\x00%d\x00%d",
+			lh.Protocol, legacyMCVersionName(lh.Protocol), motd, online, max)
+	case lh.Token1: // 1.4 / 1.5: single 0x01 byte after 0xFE, no further payload.
+		return fmt.Sprintf("%s§%d§%d", motd, online, max)
+	default: // Beta 1.8 - 1.7: bare 0xFE, no payload at all.
+		return fmt.Sprintf("%s§%d", motd, online)
+	}
+}
+
+// legacyMCVersionName returns the vanilla version string 1.6 clients expect
+// in their ping response, falling back to a generic label for unrecognized
+// legacy protocol numbers.
+func legacyMCVersionName(protocol int) string {
+	switch protocol {
+	case 78:
+		return "1.6.4"
+	case 74, 73:
+		return "1.6.2"
+	case 61, 60:
+		return "1.6.1"
+	default:
+		return "1.6"
+	}
+}
+
+// legacyKickPacket frames msg as a legacy 0xFF disconnect packet: a single
+// message ID byte followed by the UTF-16BE string, prefixed by its length in
+// characters as a big-endian uint16 (not a byte count).
+func legacyKickPacket(msg string) []byte {
+	units := utf16.Encode([]rune(msg))
+	buf := new(bytes.Buffer)
+	buf.WriteByte(0xFF)
+	_ = binary.Write(buf, binary.BigEndian, uint16(len(units)))
+	_ = binary.Write(buf, binary.BigEndian, units)
+	return buf.Bytes()
+}
+
+// closeWithLegacyKick writes a raw legacy 0xFF kick packet directly to the
+// underlying socket and closes the connection, bypassing netmc's normal
+// VarInt-framed packet-encode pipeline entirely, since legacy clients don't
+// speak it.
+func (h *handshakeSessionHandler) closeWithLegacyKick(msg string) {
+	defer func() { _ = h.conn.Close() }()
+	rawConn, ok := netmc.Assert[interface{ Conn() net.Conn }](h.conn)
+	if !ok {
+		h.log.Info("failed to assert connection as net.Conn")
+		return
+	}
+	_, _ = rawConn.Conn().Write(legacyKickPacket(msg))
+}
+
+// readLegacyKickFrame reads a complete legacy 0xFF disconnect packet from r,
+// as produced by legacyKickPacket, returning it header and all so it can be
+// relayed to a client verbatim.
+func readLegacyKickFrame(r io.Reader) ([]byte, error) {
+	header := make([]byte, 3)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	if header[0] != 0xFF {
+		return nil, fmt.Errorf("unexpected legacy response packet id %#x", header[0])
+	}
+	chars := binary.BigEndian.Uint16(header[1:3])
+	body := make([]byte, int(chars)*2)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return append(header, body...), nil
+}
+
+// forwardLegacyLite handles a legacy ping while Lite mode is enabled: if the
+// client's handshake carried a hostname (1.6), we can route it like a modern
+// connection, then either transparently relay the legacy bytes to a backend
+// that also speaks legacy (Endpoint.LegacyPassthrough), or synthesize a
+// modern status request against a modern backend and translate its response
+// back to legacy, which is the common case since most current backends no
+// longer speak the legacy ping protocol at all.
+func (h *handshakeSessionHandler) forwardLegacyLite(lh *packet.LegacyHandshake, inbound *initialInbound) {
+	defer func() { _ = h.conn.Close() }()
+
+	if lh.Hostname == "" {
+		h.log.V(1).Info("legacy ping without a hostname cannot be routed in lite mode")
+		return
+	}
+	clearedHost := lite.ClearVirtualHost(lh.Hostname)
+	log := h.log.WithName("lite").WithValues("handshakeHost", clearedHost, "legacyProtocol", lh.Protocol)
+
+	host, ep := lite.FindRoute(clearedHost, h.config().Lite.Routes...)
+	if ep == nil {
+		log.V(1).Info("no route found for host")
+		return
+	}
+	log = log.WithValues("route", host)
+
+	backend := ep.SelectBackend(h.fingerprints.Get)
+	if backend == "" {
+		log.Info("route has no backend configured")
+		return
+	}
+
+	srcConn, ok := netmc.Assert[interface{ Conn() net.Conn }](h.conn)
+	if !ok {
+		return
+	}
+	src := srcConn.Conn()
+
+	dstAddr, err := netutil.Parse(backend, src.RemoteAddr().Network())
+	if err != nil {
+		log.Error(err, "failed to parse backend address")
+		return
+	}
+	backendAddr := dstAddr.String()
+	if _, port := netutil.HostPort(dstAddr); port == 0 {
+		backendAddr = net.JoinHostPort(dstAddr.String(), "25565")
+	}
+
+	var dialer net.Dialer
+	timeout := time.Duration(h.config().ConnectionTimeout) * time.Millisecond
+	ctx, cancel := context.WithTimeout(h.conn.Context(), timeout)
+	defer cancel()
+
+	dst, err := dialer.DialContext(ctx, src.RemoteAddr().Network(), backendAddr)
+	if err != nil {
+		log.Info("failed to connect to backend", "error", err)
+		return
+	}
+	defer func() { _ = dst.Close() }()
+
+	if ep.LegacyPassthrough {
+		relayLegacyPassthrough(lh, src, dst, log)
+		return
+	}
+	relayLegacyTranslated(lh, src, dst, log)
+}
+
+// relayLegacyPassthrough forwards the raw legacy bytes as-is; the backend is
+// assumed to speak the same legacy dialect as the client.
+func relayLegacyPassthrough(lh *packet.LegacyHandshake, src, dst net.Conn, log logr.Logger) {
+	if _, err := dst.Write(lh.Raw); err != nil {
+		return
+	}
+	frame, err := readLegacyKickFrame(dst)
+	if err != nil {
+		log.V(1).Info("failed to read legacy response from backend", "error", err)
+		return
+	}
+	_, _ = src.Write(frame)
+}
+
+// relayLegacyTranslated queries dst with a synthesized modern status
+// request and translates the JSON response back into the legacy dialect lh
+// expects, for backends that no longer speak the legacy ping protocol.
+func relayLegacyTranslated(lh *packet.LegacyHandshake, src, dst net.Conn, log logr.Logger) {
+	motd, online, max, err := queryModernStatus(dst, lh.Hostname, lh.Port)
+	if err != nil {
+		log.V(1).Info("failed to query modern status from backend", "error", err)
+		return
+	}
+	_, _ = src.Write(legacyKickPacket(legacyPingReply(lh, motd, online, max)))
+}
+
+// queryModernStatus performs a modern status handshake against dst and
+// returns the MOTD and player counts from its JSON status response.
+func queryModernStatus(dst net.Conn, hostname string, port int) (motd string, online, max int, err error) {
+	hs := new(bytes.Buffer)
+	hs.WriteByte(0x00) // Handshake packet ID
+	_ = util.WriteVarInt(hs, legacyStatusProbeProtocol)
+	_ = util.WriteString(hs, hostname)
+	_ = util.WriteUint16(hs, uint16(port))
+	hs.WriteByte(0x01) // next state: status
+	if err = writeFramedPacket(dst, hs.Bytes()); err != nil {
+		return
+	}
+	if err = writeFramedPacket(dst, []byte{0x00}); err != nil { // StatusRequest
+		return
+	}
+
+	payload, err := readFramedPacket(dst)
+	if err != nil {
+		return
+	}
+	if len(payload) == 0 || payload[0] != 0x00 {
+		err = fmt.Errorf("unexpected status response packet id")
+		return
+	}
+	jsonLen, n, err := util.ReadVarInt(bytes.NewReader(payload[1:]))
+	if err != nil {
+		return
+	}
+	raw := payload[1+n:]
+	if len(raw) < jsonLen {
+		err = fmt.Errorf("truncated status json")
+		return
+	}
+
+	var status struct {
+		Description json.RawMessage `json:"description"`
+		Players     struct {
+			Online int `json:"online"`
+			Max    int `json:"max"`
+		} `json:"players"`
+	}
+	if err = json.Unmarshal(raw[:jsonLen], &status); err != nil {
+		return
+	}
+	motd = plainTextDescription(status.Description)
+	online, max = status.Players.Online, status.Players.Max
+	return
+}
+
+// plainTextDescription flattens a status response's "description" field,
+// which may be a bare string or a chat component object, into plain text.
+// Only a component's top-level "text" is used; its "extra" children are not included.
+func plainTextDescription(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var component struct {
+		Text string `json:"text"`
+	}
+	if json.Unmarshal(raw, &component) == nil {
+		return component.Text
+	}
+	return ""
+}
+
+func writeFramedPacket(w io.Writer, payload []byte) error {
+	if err := util.WriteVarInt(w, len(payload)); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+func readFramedPacket(r io.Reader) ([]byte, error) {
+	n, _, err := util.ReadVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, n)
+	_, err = io.ReadFull(r, buf)
+	return buf, err
+}
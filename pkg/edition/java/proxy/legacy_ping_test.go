@@ -0,0 +1,100 @@
+package proxy
+
+import (
+	"bytes"
+	"testing"
+
+	"go.minekube.com/gate/pkg/edition/java/proto/packet"
+)
+
+func TestLegacyKickPacketRoundTrip(t *testing.T) {
+	want := "Outdated client! Please use a supported Minecraft version."
+	frame := legacyKickPacket(want)
+
+	got, err := readLegacyKickFrame(bytes.NewReader(frame))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, frame) {
+		t.Errorf("readLegacyKickFrame returned %x; want %x", got, frame)
+	}
+	if frame[0] != 0xFF {
+		t.Errorf("legacyKickPacket packet id = %#x; want 0xFF", frame[0])
+	}
+}
+
+func TestReadLegacyKickFrameRejectsWrongPacketID(t *testing.T) {
+	if _, err := readLegacyKickFrame(bytes.NewReader([]byte{0x00, 0x00, 0x00})); err == nil {
+		t.Error("expected an error for a non-0xFF packet id")
+	}
+}
+
+func TestLegacyMCVersionName(t *testing.T) {
+	tests := []struct {
+		protocol int
+		want     string
+	}{
+		{78, "1.6.4"},
+		{74, "1.6.2"},
+		{73, "1.6.2"},
+		{61, "1.6.1"},
+		{60, "1.6.1"},
+		{999, "1.6"},
+	}
+	for _, tt := range tests {
+		if got := legacyMCVersionName(tt.protocol); got != tt.want {
+			t.Errorf("legacyMCVersionName(%d) = %q; want %q", tt.protocol, got, tt.want)
+		}
+	}
+}
+
+func TestLegacyPingReply(t *testing.T) {
+	tests := []struct {
+		name string
+		lh   *packet.LegacyHandshake
+		want string
+	}{
+		{
+			"1.6 ping host",
+			&packet.LegacyHandshake{HasPingHostData: true, Protocol: 78},
+			"§1\x00" + "78" + "\x001.6.4\x00A server\x005\x0020",
+		},
+		{
+			"1.4/1.5 token",
+			&packet.LegacyHandshake{Token1: true},
+			"A server§5§20",
+		},
+		{
+			"beta/1.7 bare ping",
+			&packet.LegacyHandshake{},
+			"A server§5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := legacyPingReply(tt.lh, "A server", 5, 20)
+			if got != tt.want {
+				t.Errorf("legacyPingReply() = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlainTextDescription(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"bare string", `"A server"`, "A server"},
+		{"chat component", `{"text":"A server"}`, "A server"},
+		{"unrecognized shape", `{"extra":[1,2,3]}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := plainTextDescription([]byte(tt.raw)); got != tt.want {
+				t.Errorf("plainTextDescription(%s) = %q; want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
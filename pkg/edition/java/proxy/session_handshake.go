@@ -7,6 +7,7 @@ import (
 	"io"
 	"net"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -16,6 +17,7 @@ import (
 	"go.minekube.com/common/minecraft/component"
 	"go.minekube.com/gate/pkg/edition/java/auth"
 	"go.minekube.com/gate/pkg/edition/java/config"
+	"go.minekube.com/gate/pkg/edition/java/fingerprint"
 	"go.minekube.com/gate/pkg/edition/java/forge"
 	"go.minekube.com/gate/pkg/edition/java/lite"
 	"go.minekube.com/gate/pkg/edition/java/netmc"
@@ -36,6 +38,8 @@ type sessionHandlerDeps struct {
 	configProvider configProvider
 	authenticator  auth.Authenticator
 	loginsQuota    *addrquota.Quota
+	fingerprints   *fingerprint.Cache
+	trustedProxies TrustedProxyConfig
 }
 
 func (d *sessionHandlerDeps) config() *config.Config {
@@ -74,7 +78,8 @@ func (h *handshakeSessionHandler) HandlePacket(p *proto.PacketContext) {
 		return
 	}
 	switch typed := p.Packet.(type) {
-	// TODO legacy pings
+	case *packet.LegacyHandshake:
+		h.handleLegacyHandshake(typed)
 	case *packet.Handshake:
 		h.handleHandshake(typed, p)
 	default:
@@ -85,11 +90,19 @@ func (h *handshakeSessionHandler) HandlePacket(p *proto.PacketContext) {
 }
 
 func (h *handshakeSessionHandler) handleHandshake(handshake *packet.Handshake, pc *proto.PacketContext) {
+	forwardedFor, err := h.applyTrustedProxyChain(handshake)
+	if err != nil {
+		h.log.Info("rejecting connection", "error", err)
+		_ = h.conn.Close()
+		return
+	}
+
 	vHost := netutil.NewAddr(
 		fmt.Sprintf("%s:%d", handshake.ServerAddress, handshake.Port),
 		h.conn.LocalAddr().Network(),
 	)
 	inbound := newInitialInbound(h.conn, vHost)
+	inbound.forwardedFor = forwardedFor
 
 	// The client sends the next wanted state in the Handshake packet.
 	nextState := stateForProtocol(handshake.NextStatus)
@@ -106,7 +119,7 @@ func (h *handshakeSessionHandler) handleHandshake(handshake *packet.Handshake, p
 
 	if h.config().Lite.Enabled {
 		// Lite mode enabled, pipe the connection.
-		h.forwardLite(handshake, pc)
+		h.forwardLite(handshake, pc, inbound)
 		return
 	}
 
@@ -183,7 +196,12 @@ func handshakeConnectionType(h *packet.Handshake) phase.ConnectionType {
 
 type initialInbound struct {
 	netmc.MinecraftConn
-	virtualHost net.Addr
+	virtualHost  net.Addr
+	forwardedFor []net.Addr
+
+	fingerprintMu sync.RWMutex
+	fingerprint   fingerprint.Result
+	fingerprinted bool
 }
 
 var _ Inbound = (*initialInbound)(nil)
@@ -199,6 +217,41 @@ func (i *initialInbound) VirtualHost() net.Addr {
 	return i.virtualHost
 }
 
+// RemoteAddr returns the resolved client address from a trusted
+// RealIP-encoded handshake, if the connection came forwarded through one;
+// otherwise the immediate peer's own socket address.
+func (i *initialInbound) RemoteAddr() net.Addr {
+	if len(i.forwardedFor) > 1 {
+		return i.forwardedFor[0]
+	}
+	return i.MinecraftConn.RemoteAddr()
+}
+
+// ForwardedFor returns the full chain of addresses this connection was
+// forwarded through, originating client first and immediate peer last, as
+// recovered from a trusted RealIP-encoded handshake. It is just the client's
+// own address when the connection wasn't forwarded through any trusted proxy.
+func (i *initialInbound) ForwardedFor() []net.Addr {
+	if len(i.forwardedFor) == 0 {
+		return []net.Addr{i.MinecraftConn.RemoteAddr()}
+	}
+	return i.forwardedFor
+}
+
+// BackendFingerprint returns the backend software fingerprint associated
+// with this connection, if one has been determined yet.
+func (i *initialInbound) BackendFingerprint() (fingerprint.Result, bool) {
+	i.fingerprintMu.RLock()
+	defer i.fingerprintMu.RUnlock()
+	return i.fingerprint, i.fingerprinted
+}
+
+func (i *initialInbound) setBackendFingerprint(r fingerprint.Result) {
+	i.fingerprintMu.Lock()
+	defer i.fingerprintMu.Unlock()
+	i.fingerprint, i.fingerprinted = r, true
+}
+
 func (i *initialInbound) Active() bool {
 	return !netmc.Closed(i.MinecraftConn)
 }
@@ -219,7 +272,7 @@ func (i *initialInbound) disconnect(reason component.Component) error {
 //
 //
 
-func (h *handshakeSessionHandler) forwardLite(handshake *packet.Handshake, pc *proto.PacketContext) {
+func (h *handshakeSessionHandler) forwardLite(handshake *packet.Handshake, pc *proto.PacketContext, inbound *initialInbound) {
 	defer func() { _ = h.conn.Close() }()
 
 	srcConn, ok := netmc.Assert[interface{ Conn() net.Conn }](h.conn)
@@ -243,7 +296,7 @@ func (h *handshakeSessionHandler) forwardLite(handshake *packet.Handshake, pc *p
 	}
 	log = log.WithValues("route", host)
 
-	backend := ep.Backend.Random()
+	backend := ep.SelectBackend(h.fingerprints.Get)
 	if backend == "" {
 		log.Info("route has no backend configured")
 		return
@@ -275,6 +328,8 @@ func (h *handshakeSessionHandler) forwardLite(handshake *packet.Handshake, pc *p
 
 	log = log.WithValues("backendAddr", netutil.Host(dst.RemoteAddr()))
 
+	go h.fingerprintBackend(backendAddr, inbound)
+
 	if ep.ProxyProtocol {
 		header := proxyproto.Header{
 			Version:           2,
@@ -295,20 +350,78 @@ func (h *handshakeSessionHandler) forwardLite(handshake *packet.Handshake, pc *p
 		update(pc, handshake)
 	}
 
-	// Forward handshake packet as is.
-	err = util.WriteVarInt(dst, len(pc.Payload))
-	if err != nil {
-		return
+	var capt *lite.Capture
+	if ep.Capture != nil {
+		connID := fmt.Sprintf("%s-%d", strings.ReplaceAll(netutil.Host(src.RemoteAddr()), ":", "_"), time.Now().UnixNano())
+		capt, err = lite.NewCapture(ep.Capture, connID, src.RemoteAddr(), dst.RemoteAddr())
+		if err != nil {
+			log.Info("failed to start packet capture", "error", err)
+		} else if capt != nil {
+			defer func() { _ = capt.Close() }()
+		}
 	}
-	_, err = dst.Write(pc.Payload)
-	if err != nil {
+
+	// Forward handshake packet as is, framed with its VarInt length prefix.
+	framed := new(bytes.Buffer)
+	_ = util.WriteVarInt(framed, len(pc.Payload))
+	framed.Write(pc.Payload)
+	if _, err = dst.Write(framed.Bytes()); err != nil {
 		return
 	}
+	if capt != nil {
+		capt.WriteClientToBackend(framed.Bytes())
+	}
+
+	// The decoder may have already buffered bytes past the handshake packet
+	// while framing it; drain those to the backend before handing the raw
+	// sockets off to the splice-friendly pump, or they'd be silently lost.
+	if buffered, ok := netmc.Assert[interface{ Buffered() []byte }](h.conn); ok {
+		if extra := buffered.Buffered(); len(extra) > 0 {
+			if _, err = dst.Write(extra); err != nil {
+				return
+			}
+			if capt != nil {
+				capt.WriteClientToBackend(extra)
+			}
+		}
+	}
 
 	log.Info("forwarding connection")
 	_ = src.SetDeadline(time.Time{}) // disable deadline
-	go func() { _, _ = io.Copy(src, dst) }()
-	_, _ = io.Copy(dst, src)
+
+	var tapClientToBackend, tapBackendToClient io.Writer
+	if capt != nil {
+		tapClientToBackend = capt.ClientToBackendWriter()
+		tapBackendToClient = capt.BackendToClientWriter()
+	}
+	if err = lite.Pump(src, dst, tapClientToBackend, tapBackendToClient); err != nil {
+		log.V(1).Info("connection pump ended", "error", err)
+	}
+}
+
+// fingerprintBackend classifies backendAddr's server software, serving a
+// cached result if one is fresh, and fires a BackendFingerprintEvent once
+// classified. It runs in its own goroutine so a slow or unresponsive probe
+// never delays the passthrough it was triggered for.
+func (h *handshakeSessionHandler) fingerprintBackend(backendAddr string, inbound *initialInbound) {
+	if h.fingerprints == nil {
+		return
+	}
+	result, ok := h.fingerprints.Get(backendAddr)
+	if !ok {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+
+		var err error
+		result, err = fingerprint.Detect(ctx, backendAddr, nil)
+		if err != nil {
+			h.log.V(1).Info("backend fingerprint probe failed", "backend", backendAddr, "error", err)
+			return
+		}
+		h.fingerprints.Set(backendAddr, result)
+	}
+	inbound.setBackendFingerprint(result)
+	h.eventMgr.Fire(&BackendFingerprintEvent{inbound: inbound, backend: backendAddr, result: result})
 }
 
 func update(pc *proto.PacketContext, h *packet.Handshake) {
@@ -0,0 +1,80 @@
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+
+	"go.minekube.com/gate/pkg/edition/java/lite"
+	"go.minekube.com/gate/pkg/edition/java/proto/packet"
+)
+
+// TrustedProxyConfig configures which upstream L4 proxies Gate accepts a
+// RealIP-encoded handshake server address from: the same "walk the header
+// chain, stop at the first untrusted hop" pattern mature HTTP front-ends
+// use. An untrusted peer presenting one is rejected outright, never
+// silently trusted.
+//
+// Inbound HAProxy PROXY v1/v2 header support is explicitly out of scope
+// here and is NOT implemented by this type or applyTrustedProxyChain: that
+// needs to unwrap the accepted net.Conn before the Minecraft decoder ever
+// reads from it, at the listener's accept loop, and no such accept loop
+// exists in this package for it to be wired into. Only the RealIP-encoded
+// handshake half of trusted-proxy support is delivered here.
+type TrustedProxyConfig struct {
+	TrustedProxies []netip.Prefix
+	MaxProxyHops   int
+}
+
+func (c TrustedProxyConfig) trusts(addr net.Addr) bool {
+	if len(c.TrustedProxies) == 0 {
+		return false
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		host = addr.String()
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	for _, prefix := range c.TrustedProxies {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyTrustedProxyChain validates and, if present, applies a RealIP-encoded
+// address carried in handshake's server address. It returns the resolved
+// forwarded chain (originating client first) for Inbound.ForwardedFor() -- whose
+// first entry initialInbound.RemoteAddr() then reports as the effective
+// client address -- or an error if an untrusted peer presented a RealIP
+// encoding.
+//
+// This deliberately doesn't rewrap h.conn to override its RemoteAddr(): a
+// wrapper embedding only the netmc.MinecraftConn interface would drop any
+// capability method not declared on that interface (e.g. Lite's raw-socket
+// access via netmc.Assert), breaking every later type assertion against
+// h.conn. initialInbound.RemoteAddr() applying the chain itself keeps h.conn
+// untouched.
+func (h *handshakeSessionHandler) applyTrustedProxyChain(handshake *packet.Handshake) ([]net.Addr, error) {
+	peer := h.conn.RemoteAddr()
+
+	original, real, ok := lite.DecodeRealIP(handshake.ServerAddress)
+	if !ok {
+		return []net.Addr{peer}, nil
+	}
+	if !h.trustedProxies.trusts(peer) {
+		return nil, fmt.Errorf("untrusted peer %s presented a RealIP-encoded handshake", peer)
+	}
+
+	chain := []net.Addr{real, peer}
+	if max := h.trustedProxies.MaxProxyHops; max > 0 && len(chain) > max {
+		return nil, fmt.Errorf("forwarded chain of %d hops exceeds MaxProxyHops=%d", len(chain), max)
+	}
+
+	handshake.ServerAddress = original
+	return chain, nil
+}
@@ -0,0 +1,38 @@
+package proxy
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+)
+
+func TestTrustedProxyConfigTrusts(t *testing.T) {
+	cfg := TrustedProxyConfig{
+		TrustedProxies: []netip.Prefix{
+			netip.MustParsePrefix("10.0.0.0/8"),
+			netip.MustParsePrefix("::1/128"),
+		},
+	}
+
+	tests := []struct {
+		name string
+		addr net.Addr
+		want bool
+	}{
+		{"trusted ipv4 with port", &net.TCPAddr{IP: net.ParseIP("10.1.2.3"), Port: 25577}, true},
+		{"trusted ipv6 with port", &net.TCPAddr{IP: net.ParseIP("::1"), Port: 25577}, true},
+		{"untrusted ipv4", &net.TCPAddr{IP: net.ParseIP("203.0.113.1"), Port: 25577}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cfg.trusts(tt.addr); got != tt.want {
+				t.Errorf("trusts(%s) = %v; want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+
+	empty := TrustedProxyConfig{}
+	if empty.trusts(&net.TCPAddr{IP: net.ParseIP("10.1.2.3"), Port: 25577}) {
+		t.Error("trusts() with no configured TrustedProxies should always be false")
+	}
+}